@@ -0,0 +1,26 @@
+// limit-calc prints the limits computed by the default scaling configuration for a given
+// memory and file descriptor budget, as json.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	rcmgr "github.com/libp2p/go-libp2p-resource-manager"
+)
+
+func main() {
+	memory := flag.Int64("memory", 1<<30, "memory available to libp2p, in bytes")
+	numFD := flag.Int("fd", 4096, "number of file descriptors available to libp2p")
+	flag.Parse()
+
+	limits := rcmgr.ComputeLimits(*memory, *numFD)
+	out, err := json.MarshalIndent(&limits, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error encoding limits: %s\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(string(out))
+}
@@ -0,0 +1,119 @@
+// Package debug provides an http handler exposing the state of a resource manager.
+package debug
+
+import (
+	"encoding/json"
+	"net/http"
+
+	rcmgr "github.com/libp2p/go-libp2p-resource-manager"
+
+	"github.com/libp2p/go-libp2p/core/network"
+)
+
+// ScopeInfo is the state of a single scope, as served by the handler.
+type ScopeInfo struct {
+	Name  string
+	Stat  network.ScopeStat
+	Limit rcmgr.Limit `json:",omitempty"`
+}
+
+// Config is the effective configuration of the limiter, as served by the handler.
+// Service, protocol and peer limits are included with their scopes.
+type Config struct {
+	System               rcmgr.Limit
+	Transient            rcmgr.Limit
+	AllowlistedSystem    rcmgr.Limit
+	AllowlistedTransient rcmgr.Limit
+	Conn                 rcmgr.Limit
+	Stream               rcmgr.Limit
+}
+
+// State is the json document served by the handler.
+type State struct {
+	Config *Config `json:",omitempty"`
+	Scopes []ScopeInfo
+}
+
+type handler struct {
+	mgr     network.ResourceManager
+	limiter rcmgr.Limiter
+}
+
+// Handler returns an http.Handler serving a json State with the usage and the effective limit of the
+// system, transient, service, protocol and peer scopes of mgr; connection and stream scopes are not
+// included. If limiter is not nil, the State also contains its configuration; it should be the
+// limiter mgr was created with. The scope query parameter restricts the output to a single scope, by
+// its name (e.g. "system", "service:foo" or "peer:Qm..."), and omits the configuration.
+// mgr must implement ResourceManagerState, as resource managers created by NewResourceManager do.
+func Handler(mgr network.ResourceManager, limiter rcmgr.Limiter) http.Handler {
+	return &handler{mgr: mgr, limiter: limiter}
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	state, ok := h.mgr.(rcmgr.ResourceManagerState)
+	if !ok {
+		http.Error(w, "resource manager does not expose its state", http.StatusNotImplemented)
+		return
+	}
+
+	filter := r.URL.Query().Get("scope")
+	var result State
+	if filter == "" && h.limiter != nil {
+		result.Config = &Config{
+			System:               h.limiter.GetSystemLimits(),
+			Transient:            h.limiter.GetTransientLimits(),
+			AllowlistedSystem:    h.limiter.GetAllowlistedSystemLimits(),
+			AllowlistedTransient: h.limiter.GetAllowlistedTransientLimits(),
+			Conn:                 h.limiter.GetConnLimits(),
+			Stream:               h.limiter.GetStreamLimits(""),
+		}
+	}
+
+	var viewErr error
+	add := func(name string, view func(func(network.ResourceScope) error) error) {
+		if viewErr != nil || (filter != "" && filter != name) {
+			return
+		}
+		viewErr = view(func(s network.ResourceScope) error {
+			info := ScopeInfo{Name: name, Stat: s.Stat()}
+			if l, ok := s.(rcmgr.ResourceScopeLimiter); ok {
+				info.Limit = l.Limit()
+			}
+			result.Scopes = append(result.Scopes, info)
+			return nil
+		})
+	}
+
+	add("system", h.mgr.ViewSystem)
+	add("transient", h.mgr.ViewTransient)
+	for _, svc := range state.ListServices() {
+		svc := svc
+		add("service:"+svc, func(f func(network.ResourceScope) error) error {
+			return h.mgr.ViewService(svc, func(s network.ServiceScope) error { return f(s) })
+		})
+	}
+	for _, proto := range state.ListProtocols() {
+		proto := proto
+		add("protocol:"+string(proto), func(f func(network.ResourceScope) error) error {
+			return h.mgr.ViewProtocol(proto, func(s network.ProtocolScope) error { return f(s) })
+		})
+	}
+	for _, p := range state.ListPeers() {
+		p := p
+		add("peer:"+p.String(), func(f func(network.ResourceScope) error) error {
+			return h.mgr.ViewPeer(p, func(s network.PeerScope) error { return f(s) })
+		})
+	}
+
+	if viewErr != nil {
+		http.Error(w, viewErr.Error(), http.StatusInternalServerError)
+		return
+	}
+	if filter != "" && len(result.Scopes) == 0 {
+		http.Error(w, "scope not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
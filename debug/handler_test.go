@@ -0,0 +1,78 @@
+package debug
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	rcmgr "github.com/libp2p/go-libp2p-resource-manager"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/test"
+
+	"github.com/stretchr/testify/require"
+)
+
+type servedState struct {
+	Config map[string]json.RawMessage
+	Scopes []struct {
+		Name  string
+		Stat  network.ScopeStat
+		Limit json.RawMessage
+	}
+}
+
+func TestHandler(t *testing.T) {
+	limiter := rcmgr.NewFixedLimiter(rcmgr.DefaultLimits.AutoScale())
+	mgr, err := rcmgr.NewResourceManager(limiter)
+	require.NoError(t, err)
+	defer mgr.Close()
+
+	p := test.RandPeerIDFatal(t)
+	conn, err := mgr.OpenConnection(network.DirInbound, true, nil)
+	require.NoError(t, err)
+	defer conn.Done()
+	require.NoError(t, conn.SetPeer(p))
+
+	srv := httptest.NewServer(Handler(mgr, limiter))
+	defer srv.Close()
+
+	get := func(query string) (int, servedState) {
+		t.Helper()
+		resp, err := http.Get(srv.URL + query)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var state servedState
+		if resp.StatusCode == http.StatusOK {
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&state))
+		}
+		return resp.StatusCode, state
+	}
+
+	status, state := get("/")
+	require.Equal(t, http.StatusOK, status)
+	require.Contains(t, state.Config, "System")
+	require.Contains(t, state.Config, "Conn")
+	stats := make(map[string]network.ScopeStat)
+	for _, s := range state.Scopes {
+		require.NotEmpty(t, s.Limit)
+		stats[s.Name] = s.Stat
+	}
+	require.Contains(t, stats, "system")
+	require.Contains(t, stats, "transient")
+	require.Contains(t, stats, "peer:"+p.String())
+	require.Equal(t, 1, stats["system"].NumConnsInbound)
+	require.Equal(t, 1, stats["system"].NumFD)
+	require.Equal(t, 1, stats["peer:"+p.String()].NumConnsInbound)
+
+	status, state = get("/?scope=peer:" + p.String())
+	require.Equal(t, http.StatusOK, status)
+	require.Nil(t, state.Config)
+	require.Len(t, state.Scopes, 1)
+	require.Equal(t, "peer:"+p.String(), state.Scopes[0].Name)
+
+	status, _ = get("/?scope=peer:unknown")
+	require.Equal(t, http.StatusNotFound, status)
+}
@@ -1,6 +1,9 @@
 package rcmgr
 
 import (
+	"errors"
+
+	"github.com/libp2p/go-libp2p/core/network"
 	rcmgr "github.com/libp2p/go-libp2p/p2p/host/resource-manager"
 )
 
@@ -14,3 +17,63 @@ type ResourceManagerState = rcmgr.ResourceManagerState
 
 // Deprecated: use github.com/libp2p/go-libp2p/p2p/host/resource-manager.ResourceManagerStat instead
 type ResourceManagerStat = rcmgr.ResourceManagerStat
+
+// ScopeHeadroom is the amount of each resource that a scope can still accept before hitting its limit.
+type ScopeHeadroom struct {
+	// StreamsInbound and StreamsOutbound take the combined Streams limit into account.
+	StreamsInbound  int
+	StreamsOutbound int
+	Streams         int
+	// ConnsInbound and ConnsOutbound take the combined Conns limit into account.
+	ConnsInbound  int
+	ConnsOutbound int
+	Conns         int
+	FD            int
+	Memory        int64
+}
+
+// ErrNoScopeLimit is returned by Headroom when the scope doesn't expose its limit.
+var ErrNoScopeLimit = errors.New("scope does not implement ResourceScopeLimiter")
+
+// Headroom returns the remaining headroom of the scope in each dimension, i.e. its limit minus its
+// current usage, clamped at zero.
+// The scope must implement ResourceScopeLimiter, which is the case for all scopes created by the
+// resource manager. The limit and usage are read separately, so concurrent activity on the scope
+// may make the result slightly stale.
+func Headroom(scope network.ResourceScope) (ScopeHeadroom, error) {
+	limiter, ok := scope.(ResourceScopeLimiter)
+	if !ok {
+		return ScopeHeadroom{}, ErrNoScopeLimit
+	}
+	limit := limiter.Limit()
+	stat := scope.Stat()
+
+	h := ScopeHeadroom{
+		Streams: headroom(limit.GetStreamTotalLimit(), stat.NumStreamsInbound+stat.NumStreamsOutbound),
+		Conns:   headroom(limit.GetConnTotalLimit(), stat.NumConnsInbound+stat.NumConnsOutbound),
+		FD:      headroom(limit.GetFDLimit(), stat.NumFD),
+	}
+	h.StreamsInbound = minInt(headroom(limit.GetStreamLimit(network.DirInbound), stat.NumStreamsInbound), h.Streams)
+	h.StreamsOutbound = minInt(headroom(limit.GetStreamLimit(network.DirOutbound), stat.NumStreamsOutbound), h.Streams)
+	h.ConnsInbound = minInt(headroom(limit.GetConnLimit(network.DirInbound), stat.NumConnsInbound), h.Conns)
+	h.ConnsOutbound = minInt(headroom(limit.GetConnLimit(network.DirOutbound), stat.NumConnsOutbound), h.Conns)
+	if mem := limit.GetMemoryLimit() - stat.Memory; mem > 0 {
+		h.Memory = mem
+	}
+
+	return h, nil
+}
+
+func headroom(limit, current int) int {
+	if current >= limit {
+		return 0
+	}
+	return limit - current
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
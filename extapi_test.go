@@ -0,0 +1,114 @@
+package rcmgr
+
+import (
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/test"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeadroom(t *testing.T) {
+	cfg := DefaultLimits.AutoScale()
+	cfg.PeerDefault = BaseLimit{
+		Streams:         12,
+		StreamsInbound:  10,
+		StreamsOutbound: 10,
+		Conns:           4,
+		ConnsInbound:    2,
+		ConnsOutbound:   2,
+		FD:              4,
+		Memory:          1 << 20,
+	}
+	mgr, err := NewResourceManager(NewFixedLimiter(cfg))
+	require.NoError(t, err)
+	defer mgr.Close()
+
+	p := test.RandPeerIDFatal(t)
+	peerHeadroom := func() ScopeHeadroom {
+		t.Helper()
+		var h ScopeHeadroom
+		require.NoError(t, mgr.ViewPeer(p, func(s network.PeerScope) error {
+			var err error
+			h, err = Headroom(s)
+			return err
+		}))
+		return h
+	}
+
+	conn, err := mgr.OpenConnection(network.DirInbound, true, nil)
+	require.NoError(t, err)
+	defer conn.Done()
+	require.NoError(t, conn.SetPeer(p))
+
+	require.Equal(t, ScopeHeadroom{
+		StreamsInbound:  10,
+		StreamsOutbound: 10,
+		Streams:         12,
+		ConnsInbound:    1,
+		ConnsOutbound:   2,
+		Conns:           3,
+		FD:              3,
+		Memory:          1 << 20,
+	}, peerHeadroom())
+
+	var streams []network.StreamManagementScope
+	defer func() {
+		for _, s := range streams {
+			s.Done()
+		}
+	}()
+	for i := 0; i < 7; i++ {
+		dir := network.DirInbound
+		if i%2 == 1 {
+			dir = network.DirOutbound
+		}
+		s, err := mgr.OpenStream(p, dir)
+		require.NoError(t, err)
+		streams = append(streams, s)
+	}
+	require.NoError(t, streams[0].ReserveMemory(256<<10, 255))
+
+	// 4 inbound and 3 outbound streams leave room for 6 and 7 more in each direction, but only for
+	// 5 in total
+	require.Equal(t, ScopeHeadroom{
+		StreamsInbound:  5,
+		StreamsOutbound: 5,
+		Streams:         5,
+		ConnsInbound:    1,
+		ConnsOutbound:   2,
+		Conns:           3,
+		FD:              3,
+		Memory:          1<<20 - 256<<10,
+	}, peerHeadroom())
+
+	// exhaust the combined Streams limit with 6 streams in each direction, below the per-direction
+	// limits of 10
+	for i := 7; i < 12; i++ {
+		dir := network.DirInbound
+		if i%2 == 1 {
+			dir = network.DirOutbound
+		}
+		s, err := mgr.OpenStream(p, dir)
+		require.NoError(t, err)
+		streams = append(streams, s)
+	}
+	h := peerHeadroom()
+	require.Zero(t, h.Streams)
+	require.Zero(t, h.StreamsInbound)
+	require.Zero(t, h.StreamsOutbound)
+	require.NoError(t, mgr.ViewPeer(p, func(s network.PeerScope) error {
+		stat := s.Stat()
+		require.Equal(t, 6, stat.NumStreamsInbound)
+		require.Equal(t, 6, stat.NumStreamsOutbound)
+		return nil
+	}))
+	_, err = mgr.OpenStream(p, network.DirInbound)
+	require.Error(t, err)
+}
+
+func TestHeadroomNoLimit(t *testing.T) {
+	_, err := Headroom(network.NullScope)
+	require.ErrorIs(t, err, ErrNoScopeLimit)
+}
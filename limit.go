@@ -10,7 +10,10 @@ allocating the resource.
 package rcmgr
 
 import (
+	"encoding/json"
+	"fmt"
 	"io"
+	"os"
 
 	rcmgr "github.com/libp2p/go-libp2p/p2p/host/resource-manager"
 )
@@ -36,6 +39,64 @@ func NewLimiterFromJSON(in io.Reader, defaults LimitConfig) (Limiter, error) {
 	return rcmgr.NewLimiterFromJSON(in, defaults)
 }
 
+// NewLimiterFromExtendedJSON creates a new limiter by parsing a json configuration, using defaults
+// for fallback. It accepts a superset of the format of NewLimiterFromJSON:
+// memory limits may be given in bytes or as strings with a unit, e.g. "128MB" or "1.5GiB", and a
+// scope's limits may be given as a multiple of the default limit of its category in defaults,
+// e.g. {"PeerDefault": {"ScaleDefault": 0.5}}.
+func NewLimiterFromExtendedJSON(in io.Reader, defaults LimitConfig) (Limiter, error) {
+	cfg, err := readLimitConfig(in, defaults, defaults)
+	if err != nil {
+		return nil, err
+	}
+	return NewFixedLimiter(cfg), nil
+}
+
+// NewLimiterFromJSONFiles creates a new limiter by parsing layered json configurations.
+// The files are applied in order, each one overriding the limits set by the previous ones,
+// using the default limits for fallback. The files are in the format of NewLimiterFromExtendedJSON,
+// with ScaleDefault resolved against the default limits.
+func NewLimiterFromJSONFiles(paths ...string) (Limiter, error) {
+	defaults := DefaultLimits.AutoScale()
+	cfg := defaults
+	for _, path := range paths {
+		layer, err := readLimitConfigFile(path, defaults, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse limit config %s: %w", path, err)
+		}
+		cfg = layer
+	}
+	return NewFixedLimiter(cfg), nil
+}
+
+func readLimitConfigFile(path string, defaults, base LimitConfig) (LimitConfig, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return LimitConfig{}, err
+	}
+	defer f.Close()
+
+	return readLimitConfig(f, defaults, base)
+}
+
+// readLimitConfig decodes a json limit configuration, resolving ScaleDefault against defaults, and
+// fills the limits it doesn't set from base.
+func readLimitConfig(in io.Reader, defaults, base LimitConfig) (LimitConfig, error) {
+	r, err := limitConfigReader(in, defaults)
+	if err != nil {
+		return LimitConfig{}, err
+	}
+	var cfg LimitConfig
+	if err := json.NewDecoder(r).Decode(&cfg); err != nil {
+		return LimitConfig{}, err
+	}
+	// LimitConfig.Apply doesn't merge the ServicePeerDefault limit, do it before the service peer
+	// limits get filled from it.
+	cfg.ServicePeerDefault.Apply(base.ServicePeerDefault)
+	cfg.Apply(base)
+	return cfg, nil
+}
+
 // Deprecated: use github.com/libp2p/go-libp2p/p2p/host/resource-manager.NewFixedLimiter instead
 func NewFixedLimiter(conf LimitConfig) Limiter {
 	return rcmgr.NewFixedLimiter(conf)
@@ -17,7 +17,24 @@ type LimitConfig = rcmgr.LimitConfig
 // Deprecated: use github.com/libp2p/go-libp2p/p2p/host/resource-manager.DefaultLimits instead
 var DefaultLimits = rcmgr.DefaultLimits
 
+// DefaultLimitsQUIC is a variant of DefaultLimits for nodes that mostly use QUIC.
+// The default 1 MiB of memory per connection keeps QUIC from growing its connection flow-control
+// window, which caps throughput. This variant allows each connection 16 MiB, enough for quic-go's
+// default maximum connection receive window of 15 MB; the stream memory limit already accommodates
+// its 6 MB stream window. All other limits are the same as DefaultLimits.
+var DefaultLimitsQUIC = func() ScalingLimitConfig {
+	cfg := DefaultLimits
+	cfg.ConnBaseLimit.Memory = 16 << 20
+	return cfg
+}()
+
 // InfiniteLimits are a limiter configuration that uses infinite limits, thus effectively not limiting anything.
 // Keep in mind that the operating system limits the number of file descriptors that an application can use.
 // Deprecated: use github.com/libp2p/go-libp2p/p2p/host/resource-manager.InfiniteLimits instead
 var InfiniteLimits = rcmgr.InfiniteLimits
+
+// ComputeLimits returns the limits that the default scaling configuration yields for a node
+// allowing libp2p to use memBytes bytes of memory and numFD file descriptors.
+func ComputeLimits(memBytes int64, numFD int) LimitConfig {
+	return DefaultLimits.Scale(memBytes, numFD)
+}
@@ -0,0 +1,52 @@
+package rcmgr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeLimits(t *testing.T) {
+	base, inc := DefaultLimits.SystemBaseLimit, DefaultLimits.SystemLimitIncrease
+
+	// below 128 MiB only the base limits apply
+	small := ComputeLimits(64<<20, 0)
+	require.Equal(t, base.Memory, small.System.Memory)
+	require.Equal(t, base.Conns, small.System.Conns)
+	require.Equal(t, base.FD, small.System.FD)
+
+	// each GiB adds one increase, and the FD limit is a fraction of the available descriptors
+	large := ComputeLimits(4<<30, 10000)
+	require.Equal(t, base.Memory+4*inc.Memory, large.System.Memory)
+	require.Equal(t, base.Conns+4*inc.Conns, large.System.Conns)
+	require.Equal(t, int(inc.FDFraction*10000), large.System.FD)
+	require.Greater(t, large.PeerDefault.StreamsInbound, small.PeerDefault.StreamsInbound)
+
+	// the other categories scale with their own increases, or not at all
+	require.Equal(t, BaseLimit{
+		Streams:         1536,
+		StreamsInbound:  768,
+		StreamsOutbound: 1536,
+		Conns:           8,
+		ConnsInbound:    4,
+		ConnsOutbound:   8,
+		FD:              156,
+		Memory:          576 << 20,
+	}, large.PeerDefault)
+	require.Equal(t, BaseLimit{
+		Conns:         1,
+		ConnsInbound:  1,
+		ConnsOutbound: 1,
+		FD:            1,
+		Memory:        1 << 20,
+	}, large.Conn)
+	require.Equal(t, large.Conn, small.Conn)
+}
+
+func TestDefaultLimitsQUIC(t *testing.T) {
+	require.GreaterOrEqual(t, DefaultLimitsQUIC.AutoScale().Conn.Memory, int64(15_000_000))
+	require.Equal(t, DefaultLimits.StreamBaseLimit, DefaultLimitsQUIC.StreamBaseLimit)
+
+	// DefaultLimits is left unchanged
+	require.Equal(t, int64(1<<20), DefaultLimits.ConnBaseLimit.Memory)
+}
@@ -0,0 +1,238 @@
+package rcmgr
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// memoryUnits are the units accepted by parseMemorySize. The SI units are decimal and the IEC
+// units binary, so "1MB" is 1000000 bytes and "1MiB" is 1048576 bytes.
+var memoryUnits = map[string]float64{
+	"":    1,
+	"b":   1,
+	"kb":  1e3,
+	"mb":  1e6,
+	"gb":  1e9,
+	"tb":  1e12,
+	"kib": 1 << 10,
+	"mib": 1 << 20,
+	"gib": 1 << 30,
+	"tib": 1 << 40,
+}
+
+// parseMemorySize parses a memory size with an optional unit, like "128MB" or "1.5GiB", into bytes.
+// Fractional results are rounded down to a whole number of bytes.
+func parseMemorySize(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if i < 0 {
+		i = len(s)
+	}
+
+	mult, ok := memoryUnits[strings.ToLower(strings.TrimSpace(s[i:]))]
+	if !ok {
+		return 0, fmt.Errorf("invalid memory size %q: unknown unit", s)
+	}
+	v, err := strconv.ParseFloat(s[:i], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid memory size %q: %w", s, err)
+	}
+	v *= mult
+	if v >= math.MaxInt64 {
+		return 0, fmt.Errorf("invalid memory size %q: overflows int64", s)
+	}
+	return int64(v), nil
+}
+
+// normalizeLimitConfigJSON reads a json limit configuration and rewrites it so that it can be
+// decoded into a LimitConfig:
+//   - memory limits given as strings with units, e.g. {"Memory": "128MB"}, are replaced by their
+//     byte values; numeric memory limits are left as they are.
+//   - limits given as a multiple of the default limit of their category, e.g.
+//     {"PeerDefault": {"ScaleDefault": 0.5}}, are resolved against defaults. Limits set explicitly
+//     alongside ScaleDefault take precedence over the scaled ones.
+func normalizeLimitConfigJSON(in io.Reader, defaults LimitConfig) ([]byte, error) {
+	dec := json.NewDecoder(in)
+	dec.UseNumber()
+	var cfg interface{}
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, err
+	}
+	if err := normalizeMemoryLimits(cfg); err != nil {
+		return nil, err
+	}
+	if err := resolveScaleDefaults(cfg, defaults); err != nil {
+		return nil, err
+	}
+	return json.Marshal(cfg)
+}
+
+func normalizeMemoryLimits(v interface{}) error {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	for k, v := range obj {
+		// encoding/json matches field names case-insensitively
+		if s, ok := v.(string); ok && strings.EqualFold(k, "Memory") {
+			mem, err := parseMemorySize(s)
+			if err != nil {
+				return err
+			}
+			obj[k] = mem
+			continue
+		}
+		if err := normalizeMemoryLimits(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolveScaleDefaults resolves the ScaleDefault limits of the scopes in cfg. Limits of single
+// scopes are scaled from the default of their own category, and entries of the per-service,
+// per-protocol and per-peer maps from the corresponding *Default limit.
+func resolveScaleDefaults(cfg interface{}, defaults LimitConfig) error {
+	obj, ok := cfg.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	scopes := map[string]BaseLimit{
+		"system":               defaults.System,
+		"transient":            defaults.Transient,
+		"allowlistedsystem":    defaults.AllowlistedSystem,
+		"allowlistedtransient": defaults.AllowlistedTransient,
+		"servicedefault":       defaults.ServiceDefault,
+		"servicepeerdefault":   defaults.ServicePeerDefault,
+		"protocoldefault":      defaults.ProtocolDefault,
+		"protocolpeerdefault":  defaults.ProtocolPeerDefault,
+		"peerdefault":          defaults.PeerDefault,
+		"conn":                 defaults.Conn,
+		"stream":               defaults.Stream,
+	}
+	scopeMaps := map[string]BaseLimit{
+		"service":      defaults.ServiceDefault,
+		"servicepeer":  defaults.ServicePeerDefault,
+		"protocol":     defaults.ProtocolDefault,
+		"protocolpeer": defaults.ProtocolPeerDefault,
+		"peer":         defaults.PeerDefault,
+	}
+
+	for k, v := range obj {
+		if base, ok := scopes[strings.ToLower(k)]; ok {
+			if err := resolveScaleDefault(k, v, base); err != nil {
+				return err
+			}
+			continue
+		}
+		if base, ok := scopeMaps[strings.ToLower(k)]; ok {
+			entries, ok := v.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			for name, entry := range entries {
+				if err := resolveScaleDefault(fmt.Sprintf("%s[%s]", k, name), entry, base); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func resolveScaleDefault(scope string, v interface{}, base BaseLimit) error {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	var scale float64
+	found := false
+	for k, v := range obj {
+		if !strings.EqualFold(k, "ScaleDefault") {
+			continue
+		}
+		n, ok := v.(json.Number)
+		if !ok {
+			return fmt.Errorf("invalid %s.ScaleDefault: not a number", scope)
+		}
+		f, err := n.Float64()
+		if err != nil || f <= 0 || math.IsInf(f, 0) {
+			return fmt.Errorf("invalid %s.ScaleDefault: %s", scope, n)
+		}
+		scale, found = f, true
+		delete(obj, k)
+	}
+	if !found {
+		return nil
+	}
+
+	scaled, err := scaleBaseLimit(base, scale)
+	if err != nil {
+		return err
+	}
+	for field, limit := range scaled {
+		explicit := false
+		for k := range obj {
+			if strings.EqualFold(k, field) {
+				explicit = true
+				break
+			}
+		}
+		if !explicit {
+			obj[field] = limit
+		}
+	}
+	return nil
+}
+
+// scaleBaseLimit returns the limits of l multiplied by scale, keyed by their json field name.
+// Non-zero limits are kept at least 1, so that they don't turn into unset ones, and are capped at
+// the largest value of their type: int for the counts and int64 for Memory.
+func scaleBaseLimit(l BaseLimit, scale float64) (map[string]int64, error) {
+	data, err := json.Marshal(l)
+	if err != nil {
+		return nil, err
+	}
+	var limits map[string]int64
+	if err := json.Unmarshal(data, &limits); err != nil {
+		return nil, err
+	}
+
+	for field, limit := range limits {
+		if limit == 0 {
+			continue
+		}
+		max := int64(math.MaxInt)
+		if field == "Memory" {
+			max = math.MaxInt64
+		}
+		switch v := float64(limit) * scale; {
+		case v >= float64(max):
+			limits[field] = max
+		case v < 1:
+			limits[field] = 1
+		default:
+			limits[field] = int64(v)
+		}
+	}
+	return limits, nil
+}
+
+// limitConfigReader returns a reader yielding the normalized json limit configuration read from in.
+func limitConfigReader(in io.Reader, defaults LimitConfig) (io.Reader, error) {
+	data, err := normalizeLimitConfigJSON(in, defaults)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(data), nil
+}
@@ -0,0 +1,122 @@
+package rcmgr
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/test"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMemorySize(t *testing.T) {
+	for _, tc := range []struct {
+		in  string
+		out int64
+	}{
+		{"1024", 1024},
+		{"512B", 512},
+		{"128MB", 128_000_000},
+		{"1.5GB", 1_500_000_000},
+		{"128MiB", 128 << 20},
+		{"1.5 GiB", 3 << 29},
+		{"4kb", 4000},
+	} {
+		mem, err := parseMemorySize(tc.in)
+		require.NoError(t, err, tc.in)
+		require.Equal(t, tc.out, mem, tc.in)
+	}
+
+	for _, in := range []string{"", "MB", "-1MB", "1XB", "NaN", "1.2.3GB", "10000000TiB"} {
+		_, err := parseMemorySize(in)
+		require.Error(t, err, in)
+	}
+}
+
+func TestNewLimiterFromExtendedJSONMemoryUnits(t *testing.T) {
+	limiter, err := NewLimiterFromExtendedJSON(strings.NewReader(`{
+		"System": {"Memory": "1.5GB"},
+		"Service": {"foo": {"memory": "128MB"}},
+		"PeerDefault": {"Memory": 4096}
+	}`), DefaultLimits.AutoScale())
+	require.NoError(t, err)
+
+	require.Equal(t, int64(1_500_000_000), limiter.GetSystemLimits().GetMemoryLimit())
+	require.Equal(t, int64(128_000_000), limiter.GetServiceLimits("foo").GetMemoryLimit())
+	require.Equal(t, int64(4096), limiter.GetPeerLimits("").GetMemoryLimit())
+
+	_, err = NewLimiterFromExtendedJSON(strings.NewReader(`{"System": {"Memory": "lots"}}`), DefaultLimits.AutoScale())
+	require.Error(t, err)
+
+	// the deprecated constructor forwards to upstream, which only accepts byte values
+	_, err = NewLimiterFromJSON(strings.NewReader(`{"System": {"Memory": "128MB"}}`), DefaultLimits.AutoScale())
+	require.Error(t, err)
+}
+
+func TestNewLimiterFromJSONFilesMemoryUnits(t *testing.T) {
+	path := writeLimitConfigFile(t, t.TempDir(), "limits.json", `{"Conn": {"Memory": "16MiB"}}`)
+	limiter, err := NewLimiterFromJSONFiles(path)
+	require.NoError(t, err)
+	require.Equal(t, int64(16<<20), limiter.GetConnLimits().GetMemoryLimit())
+}
+
+func TestNewLimiterFromExtendedJSONScaleDefault(t *testing.T) {
+	p := test.RandPeerIDFatal(t)
+	defaults := DefaultLimits.AutoScale()
+	limiter, err := NewLimiterFromExtendedJSON(strings.NewReader(`{
+		"PeerDefault": {"ScaleDefault": 0.5},
+		"Peer": {"`+p.String()+`": {"ScaleDefault": 2, "StreamsInbound": 3}},
+		"Service": {"foo": {"scaledefault": 0.25, "Memory": "1MiB"}}
+	}`), defaults)
+	require.NoError(t, err)
+
+	peerDefault := limiter.GetPeerLimits("")
+	require.Equal(t, defaults.PeerDefault.GetStreamTotalLimit()/2, peerDefault.GetStreamTotalLimit())
+	require.Equal(t, defaults.PeerDefault.GetConnLimit(network.DirInbound)/2, peerDefault.GetConnLimit(network.DirInbound))
+	require.Equal(t, defaults.PeerDefault.GetFDLimit()/2, peerDefault.GetFDLimit())
+	require.Equal(t, defaults.PeerDefault.GetMemoryLimit()/2, peerDefault.GetMemoryLimit())
+
+	// explicit limits take precedence over the scaled default
+	special := limiter.GetPeerLimits(p)
+	require.Equal(t, 3, special.GetStreamLimit(network.DirInbound))
+	require.Equal(t, 2*defaults.PeerDefault.GetStreamLimit(network.DirOutbound), special.GetStreamLimit(network.DirOutbound))
+
+	// map entries are scaled from the default of their category
+	foo := limiter.GetServiceLimits("foo")
+	require.Equal(t, defaults.ServiceDefault.GetStreamTotalLimit()/4, foo.GetStreamTotalLimit())
+	require.Equal(t, int64(1<<20), foo.GetMemoryLimit())
+
+	for _, cfg := range []string{
+		`{"PeerDefault": {"ScaleDefault": 0}}`,
+		`{"PeerDefault": {"ScaleDefault": -1}}`,
+		`{"PeerDefault": {"ScaleDefault": "half"}}`,
+	} {
+		_, err := NewLimiterFromExtendedJSON(strings.NewReader(cfg), defaults)
+		require.Error(t, err, cfg)
+	}
+}
+
+func TestScaleBaseLimit(t *testing.T) {
+	scaled, err := scaleBaseLimit(BaseLimit{Streams: 10, Conns: 1, Memory: math.MaxInt64}, 0.5)
+	require.NoError(t, err)
+	require.Equal(t, int64(5), scaled["Streams"])
+	// non-zero limits don't become unset
+	require.Equal(t, int64(1), scaled["Conns"])
+	require.Zero(t, scaled["FD"])
+
+	scaled, err = scaleBaseLimit(BaseLimit{Conns: math.MaxInt, Memory: math.MaxInt64}, 2)
+	require.NoError(t, err)
+	require.Equal(t, int64(math.MaxInt), scaled["Conns"])
+	require.Equal(t, int64(math.MaxInt64), scaled["Memory"])
+
+	// scaling infinite limits up keeps them within the range of their fields
+	limiter, err := NewLimiterFromExtendedJSON(strings.NewReader(`{"System": {"ScaleDefault": 2}}`), InfiniteLimits)
+	require.NoError(t, err)
+	system := limiter.GetSystemLimits()
+	require.Equal(t, math.MaxInt, system.GetConnTotalLimit())
+	require.Equal(t, math.MaxInt, system.GetStreamLimit(network.DirInbound))
+	require.Equal(t, math.MaxInt, system.GetFDLimit())
+	require.Equal(t, int64(math.MaxInt64), system.GetMemoryLimit())
+}
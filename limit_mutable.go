@@ -0,0 +1,142 @@
+package rcmgr
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+)
+
+// LimitCategory is a category of scopes sharing a default limit.
+type LimitCategory int
+
+const (
+	LimitCategoryService LimitCategory = iota
+	LimitCategoryServicePeer
+	LimitCategoryProtocol
+	LimitCategoryProtocolPeer
+	LimitCategoryPeer
+	LimitCategoryConn
+	LimitCategoryStream
+)
+
+// MutableLimiter is a Limiter whose default limits can be changed at runtime.
+// The resource manager asks the limiter for a limit whenever it creates a scope, so a change only
+// applies to scopes created afterwards; existing scopes keep their current limit.
+type MutableLimiter struct {
+	mx       sync.RWMutex
+	conf     LimitConfig
+	defaults map[LimitCategory]Limit
+}
+
+var _ Limiter = (*MutableLimiter)(nil)
+
+// NewMutableLimiter creates a new MutableLimiter with the limits of conf.
+func NewMutableLimiter(conf LimitConfig) *MutableLimiter {
+	serviceDefault := conf.ServiceDefault
+	servicePeerDefault := conf.ServicePeerDefault
+	protocolDefault := conf.ProtocolDefault
+	protocolPeerDefault := conf.ProtocolPeerDefault
+	peerDefault := conf.PeerDefault
+	conn := conf.Conn
+	stream := conf.Stream
+
+	return &MutableLimiter{
+		conf: conf,
+		defaults: map[LimitCategory]Limit{
+			LimitCategoryService:      &serviceDefault,
+			LimitCategoryServicePeer:  &servicePeerDefault,
+			LimitCategoryProtocol:     &protocolDefault,
+			LimitCategoryProtocolPeer: &protocolPeerDefault,
+			LimitCategoryPeer:         &peerDefault,
+			LimitCategoryConn:         &conn,
+			LimitCategoryStream:       &stream,
+		},
+	}
+}
+
+// SetDefaultLimit sets the limit used for scopes of the given category created from now on.
+// Services, protocols and peers with a specific limit in the configuration keep using it.
+// It returns an error for a nil limit or an unknown category.
+func (l *MutableLimiter) SetDefaultLimit(category LimitCategory, limit Limit) error {
+	if limit == nil {
+		return errors.New("nil default limit")
+	}
+
+	l.mx.Lock()
+	defer l.mx.Unlock()
+
+	if _, ok := l.defaults[category]; !ok {
+		return fmt.Errorf("unknown limit category: %d", category)
+	}
+	l.defaults[category] = limit
+	return nil
+}
+
+func (l *MutableLimiter) defaultLimit(category LimitCategory) Limit {
+	l.mx.RLock()
+	defer l.mx.RUnlock()
+
+	return l.defaults[category]
+}
+
+func (l *MutableLimiter) GetSystemLimits() Limit {
+	return &l.conf.System
+}
+
+func (l *MutableLimiter) GetTransientLimits() Limit {
+	return &l.conf.Transient
+}
+
+func (l *MutableLimiter) GetAllowlistedSystemLimits() Limit {
+	return &l.conf.AllowlistedSystem
+}
+
+func (l *MutableLimiter) GetAllowlistedTransientLimits() Limit {
+	return &l.conf.AllowlistedTransient
+}
+
+func (l *MutableLimiter) GetServiceLimits(svc string) Limit {
+	if sl, ok := l.conf.Service[svc]; ok {
+		return &sl
+	}
+	return l.defaultLimit(LimitCategoryService)
+}
+
+func (l *MutableLimiter) GetServicePeerLimits(svc string) Limit {
+	if pl, ok := l.conf.ServicePeer[svc]; ok {
+		return &pl
+	}
+	return l.defaultLimit(LimitCategoryServicePeer)
+}
+
+func (l *MutableLimiter) GetProtocolLimits(proto protocol.ID) Limit {
+	if pl, ok := l.conf.Protocol[proto]; ok {
+		return &pl
+	}
+	return l.defaultLimit(LimitCategoryProtocol)
+}
+
+func (l *MutableLimiter) GetProtocolPeerLimits(proto protocol.ID) Limit {
+	if pl, ok := l.conf.ProtocolPeer[proto]; ok {
+		return &pl
+	}
+	return l.defaultLimit(LimitCategoryProtocolPeer)
+}
+
+func (l *MutableLimiter) GetPeerLimits(p peer.ID) Limit {
+	if pl, ok := l.conf.Peer[p]; ok {
+		return &pl
+	}
+	return l.defaultLimit(LimitCategoryPeer)
+}
+
+func (l *MutableLimiter) GetStreamLimits(_ peer.ID) Limit {
+	return l.defaultLimit(LimitCategoryStream)
+}
+
+func (l *MutableLimiter) GetConnLimits() Limit {
+	return l.defaultLimit(LimitCategoryConn)
+}
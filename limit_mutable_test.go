@@ -0,0 +1,62 @@
+package rcmgr
+
+import (
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/test"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMutableLimiterSetDefaultLimit(t *testing.T) {
+	special := test.RandPeerIDFatal(t)
+	cfg := InfiniteLimits
+	cfg.PeerDefault.StreamsInbound = 10
+	cfg.PeerDefault.Memory = 1 << 30
+	specialLimit := cfg.PeerDefault
+	specialLimit.StreamsInbound = 5
+	cfg.Peer = map[peer.ID]BaseLimit{special: specialLimit}
+
+	limiter := NewMutableLimiter(cfg)
+	mgr, err := NewResourceManager(limiter)
+	require.NoError(t, err)
+	defer mgr.Close()
+
+	peerLimit := func(p peer.ID) int {
+		t.Helper()
+		var n int
+		require.NoError(t, mgr.ViewPeer(p, func(s network.PeerScope) error {
+			n = s.(ResourceScopeLimiter).Limit().GetStreamLimit(network.DirInbound)
+			return nil
+		}))
+		return n
+	}
+
+	// keep the existing peer scope alive, so that it isn't garbage collected and recreated
+	existing := test.RandPeerIDFatal(t)
+	conn, err := mgr.OpenConnection(network.DirInbound, false, nil)
+	require.NoError(t, err)
+	defer conn.Done()
+	require.NoError(t, conn.SetPeer(existing))
+	require.Equal(t, 10, peerLimit(existing))
+
+	newLimit := cfg.PeerDefault
+	newLimit.StreamsInbound = 20
+	require.NoError(t, limiter.SetDefaultLimit(LimitCategoryPeer, &newLimit))
+
+	require.Equal(t, 20, peerLimit(test.RandPeerIDFatal(t)))
+	require.Equal(t, 10, peerLimit(existing))
+	require.Equal(t, 5, peerLimit(special))
+}
+
+func TestMutableLimiterSetDefaultLimitInvalid(t *testing.T) {
+	limiter := NewMutableLimiter(InfiniteLimits)
+	limit := InfiniteLimits.PeerDefault
+
+	require.Error(t, limiter.SetDefaultLimit(LimitCategoryPeer, nil))
+	require.Error(t, limiter.SetDefaultLimit(LimitCategory(-1), &limit))
+	require.Error(t, limiter.SetDefaultLimit(LimitCategoryStream+1, &limit))
+	require.NotNil(t, limiter.GetPeerLimits(test.RandPeerIDFatal(t)))
+}
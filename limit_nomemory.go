@@ -0,0 +1,77 @@
+package rcmgr
+
+import (
+	"math"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+)
+
+// NewLimiterWithoutMemoryLimits wraps a limiter so that every scope has an unlimited memory limit,
+// while the stream, connection and file descriptor limits are still those of the wrapped limiter.
+// This is meant for applications that account memory themselves and only want count based
+// admission from the resource manager; reservations are still tracked in the scope stats.
+func NewLimiterWithoutMemoryLimits(l Limiter) Limiter {
+	return &noMemoryLimiter{Limiter: l}
+}
+
+type noMemoryLimiter struct {
+	Limiter
+}
+
+// unlimitedMemory is the largest memory limit for which the reservation threshold, computed as
+// (1+prio)*limit/256 by the scope, doesn't overflow.
+const unlimitedMemory = math.MaxInt64 / 256
+
+// noMemoryLimit is a Limit with the counts of the wrapped limit and no memory limit.
+type noMemoryLimit struct {
+	Limit
+}
+
+func (l noMemoryLimit) GetMemoryLimit() int64 {
+	return unlimitedMemory
+}
+
+func (l *noMemoryLimiter) GetSystemLimits() Limit {
+	return noMemoryLimit{l.Limiter.GetSystemLimits()}
+}
+
+func (l *noMemoryLimiter) GetTransientLimits() Limit {
+	return noMemoryLimit{l.Limiter.GetTransientLimits()}
+}
+
+func (l *noMemoryLimiter) GetAllowlistedSystemLimits() Limit {
+	return noMemoryLimit{l.Limiter.GetAllowlistedSystemLimits()}
+}
+
+func (l *noMemoryLimiter) GetAllowlistedTransientLimits() Limit {
+	return noMemoryLimit{l.Limiter.GetAllowlistedTransientLimits()}
+}
+
+func (l *noMemoryLimiter) GetServiceLimits(svc string) Limit {
+	return noMemoryLimit{l.Limiter.GetServiceLimits(svc)}
+}
+
+func (l *noMemoryLimiter) GetServicePeerLimits(svc string) Limit {
+	return noMemoryLimit{l.Limiter.GetServicePeerLimits(svc)}
+}
+
+func (l *noMemoryLimiter) GetProtocolLimits(proto protocol.ID) Limit {
+	return noMemoryLimit{l.Limiter.GetProtocolLimits(proto)}
+}
+
+func (l *noMemoryLimiter) GetProtocolPeerLimits(proto protocol.ID) Limit {
+	return noMemoryLimit{l.Limiter.GetProtocolPeerLimits(proto)}
+}
+
+func (l *noMemoryLimiter) GetPeerLimits(p peer.ID) Limit {
+	return noMemoryLimit{l.Limiter.GetPeerLimits(p)}
+}
+
+func (l *noMemoryLimiter) GetStreamLimits(p peer.ID) Limit {
+	return noMemoryLimit{l.Limiter.GetStreamLimits(p)}
+}
+
+func (l *noMemoryLimiter) GetConnLimits() Limit {
+	return noMemoryLimit{l.Limiter.GetConnLimits()}
+}
@@ -0,0 +1,39 @@
+package rcmgr
+
+import (
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/test"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiterWithoutMemoryLimits(t *testing.T) {
+	cfg := InfiniteLimits
+	cfg.System.Memory = 1 << 20
+	cfg.Transient.Memory = 1 << 20
+	cfg.PeerDefault.Memory = 1 << 20
+	cfg.Stream.Memory = 1 << 20
+	cfg.PeerDefault.StreamsInbound = 1
+	cfg.PeerDefault.Streams = 1
+	mgr, err := NewResourceManager(NewLimiterWithoutMemoryLimits(NewFixedLimiter(cfg)))
+	require.NoError(t, err)
+	defer mgr.Close()
+
+	p := test.RandPeerIDFatal(t)
+	s, err := mgr.OpenStream(p, network.DirInbound)
+	require.NoError(t, err)
+	defer s.Done()
+
+	// far above every configured memory limit
+	require.NoError(t, s.ReserveMemory(1<<30, network.ReservationPriorityAlways))
+	require.NoError(t, mgr.ViewSystem(func(s network.ResourceScope) error {
+		require.Equal(t, int64(1<<30), s.Stat().Memory)
+		return nil
+	}))
+
+	// the peer stream limit is still enforced
+	_, err = mgr.OpenStream(p, network.DirInbound)
+	require.Error(t, err)
+}
@@ -0,0 +1,87 @@
+package rcmgr
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/network"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeLimitConfigFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestNewLimiterFromJSONFiles(t *testing.T) {
+	dir := t.TempDir()
+	base := writeLimitConfigFile(t, dir, "base.json", `{
+		"System": {"Streams": 1000, "Conns": 100},
+		"ServicePeerDefault": {"StreamsInbound": 7}
+	}`)
+	services := writeLimitConfigFile(t, dir, "services.json", `{
+		"Service": {"foo": {"Streams": 50}}
+	}`)
+	local := writeLimitConfigFile(t, dir, "local.json", `{
+		"System": {"Conns": 99}
+	}`)
+
+	limiter, err := NewLimiterFromJSONFiles(base, services, local)
+	require.NoError(t, err)
+	defaults := DefaultLimits.AutoScale()
+
+	// later files override single fields of earlier ones
+	system := limiter.GetSystemLimits()
+	require.Equal(t, 1000, system.GetStreamTotalLimit())
+	require.Equal(t, 99, system.GetConnTotalLimit())
+	require.Equal(t, defaults.System.GetMemoryLimit(), system.GetMemoryLimit())
+
+	// entries only present in an earlier file are kept
+	foo := limiter.GetServiceLimits("foo")
+	require.Equal(t, 50, foo.GetStreamTotalLimit())
+	require.Equal(t, defaults.ServiceDefault.GetMemoryLimit(), foo.GetMemoryLimit())
+
+	// ServicePeerDefault is merged with the defaults as well
+	fooPeer := limiter.GetServicePeerLimits("foo")
+	require.Equal(t, 7, fooPeer.GetStreamLimit(network.DirInbound))
+	require.Equal(t, defaults.ServicePeerDefault.GetStreamLimit(network.DirOutbound), fooPeer.GetStreamLimit(network.DirOutbound))
+	require.Equal(t, defaults.ServicePeerDefault.GetMemoryLimit(), fooPeer.GetMemoryLimit())
+}
+
+func TestNewLimiterFromJSONFilesError(t *testing.T) {
+	dir := t.TempDir()
+	good := writeLimitConfigFile(t, dir, "good.json", `{"System": {"Conns": 99}}`)
+	bad := writeLimitConfigFile(t, dir, "bad.json", `{"System": `)
+
+	_, err := NewLimiterFromJSONFiles(good, bad)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), bad)
+	require.NotContains(t, err.Error(), good)
+
+	missing := filepath.Join(dir, "missing.json")
+	_, err = NewLimiterFromJSONFiles(good, missing)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), missing)
+}
+
+func TestNewLimiterFromExtendedJSONMatchesFiles(t *testing.T) {
+	config := `{"ServicePeerDefault": {"StreamsInbound": 7}, "Service": {"foo": {"Streams": 50}}}`
+	path := writeLimitConfigFile(t, t.TempDir(), "limits.json", config)
+
+	fromFiles, err := NewLimiterFromJSONFiles(path)
+	require.NoError(t, err)
+	fromJSON, err := NewLimiterFromExtendedJSON(strings.NewReader(config), DefaultLimits.AutoScale())
+	require.NoError(t, err)
+	require.Equal(t, fromFiles, fromJSON)
+
+	// the service peer limits not set in the file come from the defaults
+	fooPeer := fromJSON.GetServicePeerLimits("foo")
+	require.Equal(t, 7, fooPeer.GetStreamLimit(network.DirInbound))
+	require.NotZero(t, fooPeer.GetStreamLimit(network.DirOutbound))
+	require.NotZero(t, fooPeer.GetMemoryLimit())
+}
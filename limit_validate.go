@@ -0,0 +1,124 @@
+package rcmgr
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+)
+
+// ValidateLimitConfig checks that the limits of each child scope category in conf don't exceed
+// the limits of the scope they are attached to, in which case the parent limit binds first and
+// the child limit silently has no effect.
+// It returns a list of human readable warnings; an empty list means no problem was found.
+// Zero values are treated as unset and are not compared. The ServiceDefault and ProtocolDefault
+// limits aren't compared with System, as the default limits deliberately exceed it; explicitly
+// configured services and protocols are.
+func ValidateLimitConfig(conf LimitConfig) []string {
+	var warnings []string
+	check := func(child string, cl BaseLimit, parent string, pl BaseLimit) {
+		warnings = append(warnings, compareBaseLimits(child, cl, parent, pl)...)
+	}
+
+	check("Transient", conf.Transient, "System", conf.System)
+	check("AllowlistedTransient", conf.AllowlistedTransient, "AllowlistedSystem", conf.AllowlistedSystem)
+	check("PeerDefault", conf.PeerDefault, "System", conf.System)
+	check("Conn", conf.Conn, "System", conf.System)
+	// a connection scope also joins its peer scope once the peer is known
+	check("Conn", conf.Conn, "PeerDefault", conf.PeerDefault)
+	check("Stream", conf.Stream, "PeerDefault", conf.PeerDefault)
+	check("ServicePeerDefault", conf.ServicePeerDefault, "ServiceDefault", conf.ServiceDefault)
+	check("ProtocolPeerDefault", conf.ProtocolPeerDefault, "ProtocolDefault", conf.ProtocolDefault)
+
+	svcs := make([]string, 0, len(conf.Service))
+	for svc := range conf.Service {
+		svcs = append(svcs, svc)
+	}
+	sort.Strings(svcs)
+	for _, svc := range svcs {
+		check(fmt.Sprintf("Service[%s]", svc), conf.Service[svc], "System", conf.System)
+		if _, ok := conf.ServicePeer[svc]; !ok {
+			check("ServicePeerDefault", conf.ServicePeerDefault, fmt.Sprintf("Service[%s]", svc), conf.Service[svc])
+		}
+	}
+
+	svcPeers := make([]string, 0, len(conf.ServicePeer))
+	for svc := range conf.ServicePeer {
+		svcPeers = append(svcPeers, svc)
+	}
+	sort.Strings(svcPeers)
+	for _, svc := range svcPeers {
+		parent, pl := "ServiceDefault", conf.ServiceDefault
+		if l, ok := conf.Service[svc]; ok {
+			parent, pl = fmt.Sprintf("Service[%s]", svc), l
+		}
+		check(fmt.Sprintf("ServicePeer[%s]", svc), conf.ServicePeer[svc], parent, pl)
+	}
+
+	protos := make([]protocol.ID, 0, len(conf.Protocol))
+	for proto := range conf.Protocol {
+		protos = append(protos, proto)
+	}
+	sort.Slice(protos, func(i, j int) bool { return protos[i] < protos[j] })
+	for _, proto := range protos {
+		check(fmt.Sprintf("Protocol[%s]", proto), conf.Protocol[proto], "System", conf.System)
+		if _, ok := conf.ProtocolPeer[proto]; !ok {
+			check("ProtocolPeerDefault", conf.ProtocolPeerDefault, fmt.Sprintf("Protocol[%s]", proto), conf.Protocol[proto])
+		}
+	}
+
+	protoPeers := make([]protocol.ID, 0, len(conf.ProtocolPeer))
+	for proto := range conf.ProtocolPeer {
+		protoPeers = append(protoPeers, proto)
+	}
+	sort.Slice(protoPeers, func(i, j int) bool { return protoPeers[i] < protoPeers[j] })
+	for _, proto := range protoPeers {
+		parent, pl := "ProtocolDefault", conf.ProtocolDefault
+		if l, ok := conf.Protocol[proto]; ok {
+			parent, pl = fmt.Sprintf("Protocol[%s]", proto), l
+		}
+		check(fmt.Sprintf("ProtocolPeer[%s]", proto), conf.ProtocolPeer[proto], parent, pl)
+	}
+
+	peers := make([]peer.ID, 0, len(conf.Peer))
+	for p := range conf.Peer {
+		peers = append(peers, p)
+	}
+	sort.Slice(peers, func(i, j int) bool { return peers[i] < peers[j] })
+	for _, p := range peers {
+		check(fmt.Sprintf("Peer[%s]", p), conf.Peer[p], "System", conf.System)
+	}
+
+	return warnings
+}
+
+// NewValidatedFixedLimiter creates a new fixed limiter like NewFixedLimiter, but fails with an
+// error listing the warnings of ValidateLimitConfig if conf has any.
+func NewValidatedFixedLimiter(conf LimitConfig) (Limiter, error) {
+	if warnings := ValidateLimitConfig(conf); len(warnings) > 0 {
+		return nil, fmt.Errorf("invalid limit config: %s", strings.Join(warnings, "; "))
+	}
+	return NewFixedLimiter(conf), nil
+}
+
+func compareBaseLimits(child string, cl BaseLimit, parent string, pl BaseLimit) []string {
+	var warnings []string
+	cmp := func(field string, c, p int64) {
+		if c != 0 && p != 0 && c > p {
+			warnings = append(warnings, fmt.Sprintf("%s.%s (%d) exceeds %s.%s (%d)", child, field, c, parent, field, p))
+		}
+	}
+
+	cmp("Streams", int64(cl.Streams), int64(pl.Streams))
+	cmp("StreamsInbound", int64(cl.StreamsInbound), int64(pl.StreamsInbound))
+	cmp("StreamsOutbound", int64(cl.StreamsOutbound), int64(pl.StreamsOutbound))
+	cmp("Conns", int64(cl.Conns), int64(pl.Conns))
+	cmp("ConnsInbound", int64(cl.ConnsInbound), int64(pl.ConnsInbound))
+	cmp("ConnsOutbound", int64(cl.ConnsOutbound), int64(pl.ConnsOutbound))
+	cmp("FD", int64(cl.FD), int64(pl.FD))
+	cmp("Memory", cl.Memory, pl.Memory)
+
+	return warnings
+}
@@ -0,0 +1,75 @@
+package rcmgr
+
+import (
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/protocol"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateLimitConfig(t *testing.T) {
+	cfg := LimitConfig{
+		System:             BaseLimit{Streams: 100, Memory: 1 << 20},
+		ServiceDefault:     BaseLimit{Streams: 50},
+		ServicePeerDefault: BaseLimit{Streams: 20},
+		Service: map[string]BaseLimit{
+			"foo": {Streams: 10},
+			"bar": {Streams: 200},
+			"baz": {Streams: 30},
+		},
+		ServicePeer: map[string]BaseLimit{
+			"baz": {Streams: 40},
+		},
+		ProtocolDefault:     BaseLimit{Memory: 1 << 10},
+		ProtocolPeerDefault: BaseLimit{Memory: 1 << 11},
+		Protocol: map[protocol.ID]BaseLimit{
+			"/qux": {Memory: 1 << 12},
+		},
+	}
+	require.Equal(t, []string{
+		"ProtocolPeerDefault.Memory (2048) exceeds ProtocolDefault.Memory (1024)",
+		"Service[bar].Streams (200) exceeds System.Streams (100)",
+		"ServicePeerDefault.Streams (20) exceeds Service[foo].Streams (10)",
+		"ServicePeer[baz].Streams (40) exceeds Service[baz].Streams (30)",
+	}, ValidateLimitConfig(cfg))
+
+	cfg.ProtocolPeerDefault.Memory = 1 << 9
+	cfg.Service["foo"] = BaseLimit{Streams: 25}
+	cfg.Service["bar"] = BaseLimit{Streams: 60}
+	cfg.ServicePeer["baz"] = BaseLimit{Streams: 30}
+	require.Empty(t, ValidateLimitConfig(cfg))
+}
+
+func TestValidateLimitConfigConn(t *testing.T) {
+	cfg := LimitConfig{
+		System:      BaseLimit{Memory: 1 << 30},
+		PeerDefault: BaseLimit{Memory: 1 << 20},
+		Conn:        BaseLimit{Memory: 1 << 21},
+	}
+	require.Equal(t, []string{
+		"Conn.Memory (2097152) exceeds PeerDefault.Memory (1048576)",
+	}, ValidateLimitConfig(cfg))
+}
+
+func TestNewValidatedFixedLimiter(t *testing.T) {
+	cfg := LimitConfig{
+		System:    BaseLimit{Streams: 100},
+		Transient: BaseLimit{Streams: 200},
+	}
+	_, err := NewValidatedFixedLimiter(cfg)
+	require.EqualError(t, err, "invalid limit config: Transient.Streams (200) exceeds System.Streams (100)")
+
+	cfg.Transient.Streams = 50
+	limiter, err := NewValidatedFixedLimiter(cfg)
+	require.NoError(t, err)
+	require.Equal(t, 50, limiter.GetTransientLimits().GetStreamTotalLimit())
+}
+
+func TestValidateDefaultLimits(t *testing.T) {
+	require.Empty(t, ValidateLimitConfig(DefaultLimits.AutoScale()))
+	require.Empty(t, ValidateLimitConfig(DefaultLimitsQUIC.AutoScale()))
+
+	_, err := NewValidatedFixedLimiter(DefaultLimits.AutoScale())
+	require.NoError(t, err)
+}
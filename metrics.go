@@ -1,6 +1,11 @@
 package rcmgr
 
 import (
+	"sync"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
 	rcmgr "github.com/libp2p/go-libp2p/p2p/host/resource-manager"
 )
 
@@ -17,3 +22,101 @@ type metrics struct {
 func WithMetrics(reporter MetricsReporter) Option {
 	return rcmgr.WithMetrics(reporter)
 }
+
+// MockMetricsReporter is a MetricsReporter that counts how many times each of its methods
+// has been invoked, keyed by method name (e.g. "BlockStream").
+// It is intended as a test double for code using WithMetrics.
+type MockMetricsReporter struct {
+	mx     sync.Mutex
+	counts map[string]int
+}
+
+var _ MetricsReporter = (*MockMetricsReporter)(nil)
+
+// NewMockMetricsReporter creates a new MockMetricsReporter with all counts at zero.
+func NewMockMetricsReporter() *MockMetricsReporter {
+	return &MockMetricsReporter{counts: make(map[string]int)}
+}
+
+func (m *MockMetricsReporter) record(method string) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	m.counts[method]++
+}
+
+// Count returns the number of times the named method has been invoked.
+func (m *MockMetricsReporter) Count(method string) int {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	return m.counts[method]
+}
+
+// Counts returns a copy of the invocation counts of all methods invoked so far.
+func (m *MockMetricsReporter) Counts() map[string]int {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	result := make(map[string]int, len(m.counts))
+	for method, n := range m.counts {
+		result[method] = n
+	}
+	return result
+}
+
+func (m *MockMetricsReporter) AllowConn(dir network.Direction, usefd bool) {
+	m.record("AllowConn")
+}
+
+func (m *MockMetricsReporter) BlockConn(dir network.Direction, usefd bool) {
+	m.record("BlockConn")
+}
+
+func (m *MockMetricsReporter) AllowStream(p peer.ID, dir network.Direction) {
+	m.record("AllowStream")
+}
+
+func (m *MockMetricsReporter) BlockStream(p peer.ID, dir network.Direction) {
+	m.record("BlockStream")
+}
+
+func (m *MockMetricsReporter) AllowPeer(p peer.ID) {
+	m.record("AllowPeer")
+}
+
+func (m *MockMetricsReporter) BlockPeer(p peer.ID) {
+	m.record("BlockPeer")
+}
+
+func (m *MockMetricsReporter) AllowProtocol(proto protocol.ID) {
+	m.record("AllowProtocol")
+}
+
+func (m *MockMetricsReporter) BlockProtocol(proto protocol.ID) {
+	m.record("BlockProtocol")
+}
+
+func (m *MockMetricsReporter) BlockProtocolPeer(proto protocol.ID, p peer.ID) {
+	m.record("BlockProtocolPeer")
+}
+
+func (m *MockMetricsReporter) AllowService(svc string) {
+	m.record("AllowService")
+}
+
+func (m *MockMetricsReporter) BlockService(svc string) {
+	m.record("BlockService")
+}
+
+func (m *MockMetricsReporter) BlockServicePeer(svc string, p peer.ID) {
+	m.record("BlockServicePeer")
+}
+
+func (m *MockMetricsReporter) AllowMemory(size int) {
+	m.record("AllowMemory")
+}
+
+func (m *MockMetricsReporter) BlockMemory(size int) {
+	m.record("BlockMemory")
+}
@@ -0,0 +1,31 @@
+package rcmgr
+
+import (
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/test"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMockMetricsReporter(t *testing.T) {
+	cfg := DefaultLimits.AutoScale()
+	cfg.PeerDefault.StreamsInbound = 1
+	m := NewMockMetricsReporter()
+	mgr, err := NewResourceManager(NewFixedLimiter(cfg), WithMetrics(m))
+	require.NoError(t, err)
+	defer mgr.Close()
+
+	p := test.RandPeerIDFatal(t)
+	s, err := mgr.OpenStream(p, network.DirInbound)
+	require.NoError(t, err)
+	defer s.Done()
+	require.Equal(t, 1, m.Count("AllowStream"))
+
+	_, err = mgr.OpenStream(p, network.DirInbound)
+	require.Error(t, err)
+	require.Equal(t, 1, m.Count("BlockStream"))
+	require.Equal(t, 1, m.Count("AllowStream"))
+	require.Equal(t, 1, m.Counts()["BlockStream"])
+}
@@ -2,11 +2,20 @@
 package rcmgr
 
 import (
+	"context"
+	"fmt"
+	"strings"
+
 	"github.com/libp2p/go-libp2p/core/network"
 	"github.com/libp2p/go-libp2p/core/peer"
 	rcmgr "github.com/libp2p/go-libp2p/p2p/host/resource-manager"
+
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/multiformats/go-multiaddr"
 )
 
+var log = logging.Logger("rcmgr")
+
 // Deprecated: use github.com/libp2p/go-libp2p/p2p/host/resource-manager.Option instead
 type Option = rcmgr.Option
 
@@ -15,6 +24,50 @@ func NewResourceManager(limits Limiter, opts ...Option) (network.ResourceManager
 	return rcmgr.NewResourceManager(limits, opts...)
 }
 
+// OpenConnectionWithContext opens a connection scope like mgr.OpenConnection, tying the admission to ctx.
+// If ctx is done before or during admission its error is returned, and no reservation is held.
+func OpenConnectionWithContext(ctx context.Context, mgr network.ResourceManager, dir network.Direction, usefd bool, endpoint multiaddr.Multiaddr) (network.ConnManagementScope, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	scope, err := mgr.OpenConnection(dir, usefd, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		scope.Done()
+		return nil, err
+	}
+
+	return scope, nil
+}
+
+// OpenStreams opens n stream scopes for peer p in direction dir, either all of them or none.
+// If any of them can't be opened, the ones already opened are released and the error is returned.
+// Note that the streams are admitted one at a time, so other reservations may briefly observe
+// a partially opened batch.
+func OpenStreams(mgr network.ResourceManager, p peer.ID, dir network.Direction, n int) ([]network.StreamManagementScope, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("invalid number of streams: %d", n)
+	}
+
+	scopes := make([]network.StreamManagementScope, 0, n)
+	for i := 0; i < n; i++ {
+		scope, err := mgr.OpenStream(p, dir)
+		if err != nil {
+			for _, s := range scopes {
+				s.Done()
+			}
+			return nil, err
+		}
+		scopes = append(scopes, scope)
+	}
+
+	return scopes, nil
+}
+
 // GetAllowlist tries to get the allowlist from the given resourcemanager
 // interface by checking to see if its concrete type is a resourceManager.
 // Returns nil if it fails to get the allowlist.
@@ -33,6 +86,11 @@ func IsTransientScope(name string) bool {
 	return rcmgr.IsTransientScope(name)
 }
 
+// Deprecated: use github.com/libp2p/go-libp2p/p2p/host/resource-manager.IsStreamScope instead
+func IsStreamScope(name string) bool {
+	return rcmgr.IsStreamScope(name)
+}
+
 // Deprecated: use github.com/libp2p/go-libp2p/p2p/host/resource-manager.IsConnScope instead
 func IsConnScope(name string) bool {
 	return rcmgr.IsConnScope(name)
@@ -57,3 +115,92 @@ func ParseServiceScopeName(name string) string {
 func ParseProtocolScopeName(name string) string {
 	return rcmgr.ParseProtocolScopeName(name)
 }
+
+// ScopeClass is the class of a resource scope, as derived from its name.
+type ScopeClass string
+
+const (
+	ScopeClassUnknown              ScopeClass = ""
+	ScopeClassSystem               ScopeClass = "system"
+	ScopeClassTransient            ScopeClass = "transient"
+	ScopeClassAllowlistedSystem    ScopeClass = "allowlistedSystem"
+	ScopeClassAllowlistedTransient ScopeClass = "allowlistedTransient"
+	ScopeClassConn                 ScopeClass = "conn"
+	ScopeClassStream               ScopeClass = "stream"
+	ScopeClassPeer                 ScopeClass = "peer"
+	ScopeClassService              ScopeClass = "service"
+	ScopeClassServicePeer          ScopeClass = "service-peer"
+	ScopeClassProtocol             ScopeClass = "protocol"
+	ScopeClassProtocolPeer         ScopeClass = "protocol-peer"
+)
+
+// ClassifyScope returns the class of the scope with the given name, or ScopeClassUnknown if the name
+// isn't one the resource manager generates.
+// Spans are classified as the scope they belong to; use IsSpan to tell them apart.
+func ClassifyScope(name string) ScopeClass {
+	name = spanOwner(name)
+
+	switch {
+	case name == "system":
+		return ScopeClassSystem
+	case name == "transient":
+		return ScopeClassTransient
+	case name == "allowlistedSystem":
+		return ScopeClassAllowlistedSystem
+	case name == "allowlistedTransient":
+		return ScopeClassAllowlistedTransient
+	case strings.HasPrefix(name, "conn-"):
+		return ScopeClassConn
+	case strings.HasPrefix(name, "stream-"):
+		return ScopeClassStream
+	case strings.HasPrefix(name, "peer:"):
+		return ScopeClassPeer
+	case strings.HasPrefix(name, "service:"):
+		if strings.Contains(name, ".peer:") {
+			return ScopeClassServicePeer
+		}
+		return ScopeClassService
+	case strings.HasPrefix(name, "protocol:"):
+		if strings.Contains(name, ".peer:") {
+			return ScopeClassProtocolPeer
+		}
+		return ScopeClassProtocol
+	default:
+		return ScopeClassUnknown
+	}
+}
+
+// ParseServicePeerScopeName returns the service and peer of a service peer scope name.
+// Otherwise returns "", ""
+func ParseServicePeerScopeName(name string) (string, peer.ID) {
+	return parseCompoundPeerScopeName(name, "service:")
+}
+
+// ParseProtocolPeerScopeName returns the protocol and peer of a protocol peer scope name.
+// Otherwise returns "", ""
+func ParseProtocolPeerScopeName(name string) (string, peer.ID) {
+	return parseCompoundPeerScopeName(name, "protocol:")
+}
+
+func parseCompoundPeerScopeName(name, prefix string) (string, peer.ID) {
+	if !strings.HasPrefix(name, prefix) || IsSpan(name) {
+		return "", ""
+	}
+	idx := strings.LastIndex(name, ".peer:")
+	if idx < 0 {
+		return "", ""
+	}
+	p, err := peer.Decode(name[idx+len(".peer:"):])
+	if err != nil {
+		return "", ""
+	}
+	return name[len(prefix):idx], p
+}
+
+// spanOwner strips the span suffix from the name of a span scope.
+func spanOwner(name string) string {
+	if idx := strings.Index(name, ".span-"); idx > -1 {
+		return name[:idx]
+	}
+	return name
+}
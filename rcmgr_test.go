@@ -0,0 +1,269 @@
+package rcmgr
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	"github.com/libp2p/go-libp2p/core/test"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenStreams(t *testing.T) {
+	cfg := InfiniteLimits
+	cfg.System.StreamsInbound = 4
+	cfg.System.Streams = 4
+	mgr, err := NewResourceManager(NewFixedLimiter(cfg))
+	require.NoError(t, err)
+	defer mgr.Close()
+
+	p := test.RandPeerIDFatal(t)
+	checkStreams := func(n int) {
+		t.Helper()
+		for _, view := range []func(func(network.ResourceScope) error) error{mgr.ViewSystem, mgr.ViewTransient} {
+			require.NoError(t, view(func(s network.ResourceScope) error {
+				require.Equal(t, n, s.Stat().NumStreamsInbound)
+				return nil
+			}))
+		}
+		require.NoError(t, mgr.ViewPeer(p, func(s network.PeerScope) error {
+			require.Equal(t, n, s.Stat().NumStreamsInbound)
+			return nil
+		}))
+	}
+
+	// the system fits one stream less than the batch, so nothing is admitted, and the streams
+	// opened before the failure are released from the peer as well
+	scopes, err := OpenStreams(mgr, p, network.DirInbound, 5)
+	require.Error(t, err)
+	require.Nil(t, scopes)
+	checkStreams(0)
+
+	_, err = OpenStreams(mgr, p, network.DirInbound, -1)
+	require.Error(t, err)
+
+	scopes, err = OpenStreams(mgr, p, network.DirInbound, 4)
+	require.NoError(t, err)
+	require.Len(t, scopes, 4)
+	checkStreams(4)
+
+	for _, s := range scopes {
+		s.Done()
+	}
+	checkStreams(0)
+}
+
+func TestOpenConnectionWithContextCancelled(t *testing.T) {
+	mgr, err := NewResourceManager(NewFixedLimiter(DefaultLimits.AutoScale()))
+	require.NoError(t, err)
+	defer mgr.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	conn, err := OpenConnectionWithContext(ctx, mgr, network.DirInbound, true, nil)
+	require.ErrorIs(t, err, context.Canceled)
+	require.Nil(t, conn)
+
+	for _, view := range []func(func(network.ResourceScope) error) error{mgr.ViewSystem, mgr.ViewTransient} {
+		require.NoError(t, view(func(s network.ResourceScope) error {
+			stat := s.Stat()
+			require.Zero(t, stat.NumConnsInbound)
+			require.Zero(t, stat.NumFD)
+			return nil
+		}))
+	}
+}
+
+func TestScopeAccountingStress(t *testing.T) {
+	// the memory limit of InfiniteLimits overflows the reservation threshold for high priorities
+	mgr, err := NewResourceManager(NewLimiterWithoutMemoryLimits(NewFixedLimiter(InfiniteLimits)))
+	require.NoError(t, err)
+	defer mgr.Close()
+
+	peers := make([]peer.ID, 4)
+	for i := range peers {
+		peers[i] = test.RandPeerIDFatal(t)
+	}
+	svcs := []string{"svc-a", "svc-b"}
+	protos := []protocol.ID{"/proto/a", "/proto/b"}
+
+	var wg sync.WaitGroup
+	for w := 0; w < 16; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				p := peers[(w+i)%len(peers)]
+
+				conn, err := mgr.OpenConnection(network.DirInbound, true, nil)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if err := conn.SetPeer(p); err != nil {
+					t.Error(err)
+				}
+
+				for j := 0; j < 3; j++ {
+					dir := network.DirInbound
+					if j%2 == 1 {
+						dir = network.DirOutbound
+					}
+					s, err := mgr.OpenStream(p, dir)
+					if err != nil {
+						t.Error(err)
+						continue
+					}
+					if err := s.SetProtocol(protos[j%len(protos)]); err != nil {
+						t.Error(err)
+					}
+					if err := s.SetService(svcs[(w+j)%len(svcs)]); err != nil {
+						t.Error(err)
+					}
+					if err := s.ReserveMemory(1024, network.ReservationPriorityAlways); err != nil {
+						t.Error(err)
+					}
+					s.Done()
+				}
+
+				// views take and drop references to the scopes concurrently with the above
+				_ = mgr.ViewPeer(p, func(network.PeerScope) error { return nil })
+				_ = mgr.ViewService(svcs[i%len(svcs)], func(network.ServiceScope) error { return nil })
+				_ = mgr.ViewProtocol(protos[i%len(protos)], func(network.ProtocolScope) error { return nil })
+
+				conn.Done()
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	checkZero := func(name string, stat network.ScopeStat) {
+		t.Helper()
+		require.Equal(t, network.ScopeStat{}, stat, name)
+	}
+	require.NoError(t, mgr.ViewSystem(func(s network.ResourceScope) error {
+		checkZero("system", s.Stat())
+		return nil
+	}))
+	require.NoError(t, mgr.ViewTransient(func(s network.ResourceScope) error {
+		checkZero("transient", s.Stat())
+		return nil
+	}))
+	for _, p := range peers {
+		require.NoError(t, mgr.ViewPeer(p, func(s network.PeerScope) error {
+			checkZero(fmt.Sprintf("peer %s", p), s.Stat())
+			return nil
+		}))
+	}
+	for _, svc := range svcs {
+		require.NoError(t, mgr.ViewService(svc, func(s network.ServiceScope) error {
+			checkZero("service "+svc, s.Stat())
+			return nil
+		}))
+	}
+	for _, proto := range protos {
+		require.NoError(t, mgr.ViewProtocol(proto, func(s network.ProtocolScope) error {
+			checkZero(fmt.Sprintf("protocol %s", proto), s.Stat())
+			return nil
+		}))
+	}
+}
+
+// cancelOnSecondCheck is a context that reports cancellation from the second call to Err on, i.e.
+// after OpenConnectionWithContext has admitted the connection.
+type cancelOnSecondCheck struct {
+	context.Context
+	checks int
+}
+
+func (c *cancelOnSecondCheck) Err() error {
+	c.checks++
+	if c.checks > 1 {
+		return context.Canceled
+	}
+	return nil
+}
+
+func TestOpenConnectionWithContextCancelledDuringAdmission(t *testing.T) {
+	mgr, err := NewResourceManager(NewFixedLimiter(DefaultLimits.AutoScale()))
+	require.NoError(t, err)
+	defer mgr.Close()
+
+	ctx := &cancelOnSecondCheck{Context: context.Background()}
+	conn, err := OpenConnectionWithContext(ctx, mgr, network.DirInbound, true, nil)
+	require.ErrorIs(t, err, context.Canceled)
+	require.Nil(t, conn)
+	require.Equal(t, 2, ctx.checks)
+
+	// the connection admitted before the cancellation was noticed has been released
+	for _, view := range []func(func(network.ResourceScope) error) error{mgr.ViewSystem, mgr.ViewTransient} {
+		require.NoError(t, view(func(s network.ResourceScope) error {
+			stat := s.Stat()
+			require.Zero(t, stat.NumConnsInbound)
+			require.Zero(t, stat.NumFD)
+			return nil
+		}))
+	}
+}
+
+func TestClassifyScope(t *testing.T) {
+	p := test.RandPeerIDFatal(t)
+
+	for _, tc := range []struct {
+		name  string
+		class ScopeClass
+	}{
+		{"system", ScopeClassSystem},
+		{"transient", ScopeClassTransient},
+		{"allowlistedSystem", ScopeClassAllowlistedSystem},
+		{"allowlistedTransient", ScopeClassAllowlistedTransient},
+		{"conn-1", ScopeClassConn},
+		{"stream-2", ScopeClassStream},
+		{"peer:" + p.String(), ScopeClassPeer},
+		{"service:foo", ScopeClassService},
+		{"service:foo.peer:" + p.String(), ScopeClassServicePeer},
+		{"protocol:/foo/1.0.0", ScopeClassProtocol},
+		{"protocol:/foo/1.0.0.peer:" + p.String(), ScopeClassProtocolPeer},
+		{"conn-1.span-3", ScopeClassConn},
+		{"stream-2.span-1", ScopeClassStream},
+		{"service:foo.peer:" + p.String() + ".span-1", ScopeClassServicePeer},
+		{"", ScopeClassUnknown},
+		{"foo", ScopeClassUnknown},
+	} {
+		require.Equal(t, tc.class, ClassifyScope(tc.name), tc.name)
+	}
+}
+
+func TestParseCompoundPeerScopeNames(t *testing.T) {
+	p := test.RandPeerIDFatal(t)
+
+	svc, sp := ParseServicePeerScopeName("service:foo.bar.peer:" + p.String())
+	require.Equal(t, "foo.bar", svc)
+	require.Equal(t, p, sp)
+
+	proto, pp := ParseProtocolPeerScopeName("protocol:/foo/1.0.0.peer:" + p.String())
+	require.Equal(t, "/foo/1.0.0", proto)
+	require.Equal(t, p, pp)
+
+	for _, name := range []string{
+		"service:foo",
+		"service:foo.peer:invalid",
+		"service:foo.peer:" + p.String() + ".span-1",
+		"protocol:/foo/1.0.0.peer:" + p.String(),
+		"peer:" + p.String(),
+	} {
+		svc, sp := ParseServicePeerScopeName(name)
+		require.Empty(t, svc, name)
+		require.Equal(t, peer.ID(""), sp, name)
+	}
+
+	proto, pp = ParseProtocolPeerScopeName("service:foo.peer:" + p.String())
+	require.Empty(t, proto)
+	require.Equal(t, peer.ID(""), pp)
+}
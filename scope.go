@@ -1,6 +1,9 @@
 package rcmgr
 
 import (
+	"sync"
+
+	"github.com/libp2p/go-libp2p/core/network"
 	rcmgr "github.com/libp2p/go-libp2p/p2p/host/resource-manager"
 )
 
@@ -9,3 +12,31 @@ import (
 func IsSpan(name string) bool {
 	return rcmgr.IsSpan(name)
 }
+
+// Reservation is a memory reservation in a scope, created by Reserve.
+type Reservation struct {
+	scope network.ResourceScope
+	size  int
+	once  sync.Once
+}
+
+// Reserve reserves size bytes of memory with priority prio in scope, and returns the reservation
+// as a handle that releases exactly that amount.
+func Reserve(scope network.ResourceScope, size int, prio uint8) (*Reservation, error) {
+	if err := scope.ReserveMemory(size, prio); err != nil {
+		return nil, err
+	}
+	return &Reservation{scope: scope, size: size}, nil
+}
+
+// Size returns the amount of reserved memory.
+func (r *Reservation) Size() int {
+	return r.size
+}
+
+// Release releases the reserved memory. Only the first call has an effect.
+func (r *Reservation) Release() {
+	r.once.Do(func() {
+		r.scope.ReleaseMemory(r.size)
+	})
+}
@@ -0,0 +1,41 @@
+package rcmgr
+
+import (
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/network"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReservation(t *testing.T) {
+	mgr, err := NewResourceManager(NewFixedLimiter(DefaultLimits.AutoScale()))
+	require.NoError(t, err)
+	defer mgr.Close()
+
+	conn, err := mgr.OpenConnection(network.DirInbound, false, nil)
+	require.NoError(t, err)
+	defer conn.Done()
+
+	r1, err := Reserve(conn, 1024, 255)
+	require.NoError(t, err)
+	require.Equal(t, 1024, r1.Size())
+	r2, err := Reserve(conn, 4096, 255)
+	require.NoError(t, err)
+	require.Equal(t, int64(1024+4096), conn.Stat().Memory)
+
+	r1.Release()
+	require.Equal(t, int64(4096), conn.Stat().Memory)
+	// releasing twice doesn't release the memory of the other reservation
+	r1.Release()
+	require.Equal(t, int64(4096), conn.Stat().Memory)
+
+	r2.Release()
+	require.Equal(t, int64(0), conn.Stat().Memory)
+
+	// a failed reservation returns no handle and reserves nothing
+	r, err := Reserve(conn, 1<<30, 255)
+	require.Error(t, err)
+	require.Nil(t, r)
+	require.Equal(t, int64(0), conn.Stat().Memory)
+}
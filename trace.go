@@ -1,6 +1,10 @@
 package rcmgr
 
 import (
+	"hash/fnv"
+	"math"
+	"math/rand"
+
 	rcmgr "github.com/libp2p/go-libp2p/p2p/host/resource-manager"
 )
 
@@ -37,3 +41,59 @@ const (
 
 // Deprecated: use github.com/libp2p/go-libp2p/p2p/host/resource-manager.TraceEvt instead
 type TraceEvt = rcmgr.TraceEvt
+
+// SamplingTraceReporter is a TraceReporter that forwards only a fraction of the usage events
+// (memory reservations and releases, stream and connection additions and removals) to an inner
+// reporter. Start, scope lifecycle and block events are always forwarded.
+type SamplingTraceReporter struct {
+	inner    TraceReporter
+	rate     float64
+	perScope bool
+}
+
+var _ TraceReporter = (*SamplingTraceReporter)(nil)
+
+// NewSamplingTraceReporter creates a SamplingTraceReporter forwarding each usage event to inner
+// with probability rate, which is expected to be >= 0 and <= 1.
+func NewSamplingTraceReporter(inner TraceReporter, rate float64) *SamplingTraceReporter {
+	return &SamplingTraceReporter{inner: inner, rate: rate}
+}
+
+// NewScopeSamplingTraceReporter creates a SamplingTraceReporter that samples by scope rather than by
+// event: either all or none of the usage events of a given scope are forwarded to inner, with
+// a fraction rate of the scopes being selected. The decision is deterministic for a scope name, and
+// span scopes follow the scope they belong to.
+func NewScopeSamplingTraceReporter(inner TraceReporter, rate float64) *SamplingTraceReporter {
+	return &SamplingTraceReporter{inner: inner, rate: rate, perScope: true}
+}
+
+// ConsumeEvent implements TraceReporter.
+func (r *SamplingTraceReporter) ConsumeEvent(evt TraceEvt) {
+	switch evt.Type {
+	case TraceReserveMemoryEvt, TraceReleaseMemoryEvt,
+		TraceAddStreamEvt, TraceRemoveStreamEvt,
+		TraceAddConnEvt, TraceRemoveConnEvt:
+		if !r.sample(spanOwner(evt.Name)) {
+			return
+		}
+	}
+	r.inner.ConsumeEvent(evt)
+}
+
+func (r *SamplingTraceReporter) sample(scope string) bool {
+	if !r.perScope {
+		return rand.Float64() < r.rate
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(scope))
+	// fnv doesn't spread similar short names evenly over the high bits, so finalize the hash
+	// (as in murmur3) before scaling it to [0, 1].
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return float64(x)/math.MaxUint64 < r.rate
+}
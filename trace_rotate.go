@@ -0,0 +1,376 @@
+package rcmgr
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	traceSegmentPrefix = "rcmgr-trace-"
+	traceSegmentSuffix = ".json.gz"
+)
+
+// RotatingTraceReporter is a TraceReporter that writes gzipped json trace events, in the same
+// format as WithTrace, to a sequence of segment files in a directory.
+// A new segment is started once the current one reaches maxBytes, and at most maxFiles segments
+// are kept; the oldest ones are deleted. Events are written in batches, so a segment may exceed
+// maxBytes by up to one batch.
+type RotatingTraceReporter struct {
+	dir      string
+	maxBytes int64
+	maxFiles int
+
+	mx      sync.Mutex
+	done    bool
+	pending []TraceEvt
+	started bool
+	closeCh chan struct{}
+	wg      sync.WaitGroup
+	// err is the last error of the background writer, returned by Close
+	err error
+
+	out     *os.File
+	cw      *countingWriter
+	gzOut   *gzip.Writer
+	jsonOut *json.Encoder
+	seq     int
+}
+
+var _ TraceReporter = (*RotatingTraceReporter)(nil)
+
+// NewRotatingTraceReporter creates a new RotatingTraceReporter writing to dir, which is created if
+// it doesn't exist. Segment numbering continues after the segments already present in dir.
+// Events are written in the background once per second; Close must be called to flush the
+// remaining events and finalize the current segment.
+func NewRotatingTraceReporter(dir string, maxBytes int64, maxFiles int) (*RotatingTraceReporter, error) {
+	if maxBytes <= 0 {
+		return nil, fmt.Errorf("invalid maximum trace segment size: %d", maxBytes)
+	}
+	if maxFiles <= 0 {
+		return nil, fmt.Errorf("invalid maximum number of trace segments: %d", maxFiles)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	segments, err := traceSegments(dir)
+	if err != nil {
+		return nil, err
+	}
+	var seq int
+	if len(segments) > 0 {
+		seq, _ = traceSegmentSeq(filepath.Base(segments[len(segments)-1]))
+	}
+
+	return &RotatingTraceReporter{
+		dir:      dir,
+		maxBytes: maxBytes,
+		maxFiles: maxFiles,
+		closeCh:  make(chan struct{}),
+		seq:      seq,
+	}, nil
+}
+
+// WithTraceRotating is a resource manager option that writes the trace to dir with a
+// RotatingTraceReporter. The returned io.Closer must be closed after the resource manager, to flush
+// the remaining events and finalize the current segment.
+func WithTraceRotating(dir string, maxBytes int64, maxFiles int) (Option, io.Closer, error) {
+	r, err := NewRotatingTraceReporter(dir, maxBytes, maxFiles)
+	if err != nil {
+		return nil, nil, err
+	}
+	return WithTraceReporter(r), r, nil
+}
+
+// ConsumeEvent implements TraceReporter.
+func (r *RotatingTraceReporter) ConsumeEvent(evt TraceEvt) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	if r.done {
+		return
+	}
+	if !r.started {
+		r.started = true
+		r.wg.Add(1)
+		go r.background()
+	}
+	r.pending = append(r.pending, evt)
+}
+
+// Close flushes the pending events and finalizes the current segment. It returns the last error
+// encountered while writing the trace in the background, if any.
+func (r *RotatingTraceReporter) Close() error {
+	r.mx.Lock()
+	if r.done {
+		// either already closed, or the background writer stopped after an error
+		r.mx.Unlock()
+		r.wg.Wait()
+		return r.err
+	}
+	r.done = true
+	close(r.closeCh)
+	started := r.started
+	r.mx.Unlock()
+
+	if !started {
+		r.err = r.closeSegment()
+		return r.err
+	}
+	r.wg.Wait()
+	return r.err
+}
+
+func (r *RotatingTraceReporter) background() {
+	defer r.wg.Done()
+	defer func() {
+		if err := r.closeSegment(); err != nil {
+			log.Warnf("error closing rcmgr trace segment: %s", err)
+			r.err = err
+		}
+	}()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	var pend []TraceEvt
+
+	getEvents := func() {
+		r.mx.Lock()
+		tmp := r.pending
+		r.pending = pend[:0]
+		pend = tmp
+		r.mx.Unlock()
+	}
+
+	for {
+		select {
+		case <-ticker.C:
+			getEvents()
+			if err := r.writeEvents(pend); err != nil {
+				log.Warnf("error writing rcmgr trace: %s", err)
+				r.err = err
+				r.mx.Lock()
+				r.done = true
+				r.mx.Unlock()
+				return
+			}
+
+		case <-r.closeCh:
+			getEvents()
+			if err := r.writeEvents(pend); err != nil {
+				log.Warnf("error writing rcmgr trace: %s", err)
+				r.err = err
+			}
+			return
+		}
+	}
+}
+
+// writeEvents writes a batch of events to the current segment, and starts a new segment
+// afterwards if the current one has reached maxBytes.
+func (r *RotatingTraceReporter) writeEvents(pend []TraceEvt) error {
+	if len(pend) == 0 {
+		return nil
+	}
+
+	if r.gzOut == nil {
+		if err := r.openSegment(); err != nil {
+			return err
+		}
+	}
+
+	for _, evt := range pend {
+		if err := r.jsonOut.Encode(evt); err != nil {
+			return err
+		}
+	}
+	if err := r.gzOut.Flush(); err != nil {
+		return err
+	}
+
+	if r.cw.n >= r.maxBytes {
+		return r.closeSegment()
+	}
+	return nil
+}
+
+func (r *RotatingTraceReporter) openSegment() error {
+	r.seq++
+	name := fmt.Sprintf("%s%010d%s", traceSegmentPrefix, r.seq, traceSegmentSuffix)
+	out, err := os.OpenFile(filepath.Join(r.dir, name), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	r.out = out
+	r.cw = &countingWriter{w: out}
+	r.gzOut = gzip.NewWriter(r.cw)
+	r.jsonOut = json.NewEncoder(r.gzOut)
+
+	return r.prune()
+}
+
+func (r *RotatingTraceReporter) closeSegment() error {
+	if r.gzOut == nil {
+		return nil
+	}
+
+	err := r.gzOut.Close()
+	if err2 := r.out.Close(); err == nil {
+		err = err2
+	}
+	r.gzOut, r.jsonOut, r.cw, r.out = nil, nil, nil, nil
+	return err
+}
+
+// prune deletes the oldest segments so that at most maxFiles remain, including the current one.
+func (r *RotatingTraceReporter) prune() error {
+	segments, err := traceSegments(r.dir)
+	if err != nil {
+		return err
+	}
+
+	for len(segments) > r.maxFiles {
+		if err := os.Remove(segments[0]); err != nil {
+			return err
+		}
+		segments = segments[1:]
+	}
+
+	return nil
+}
+
+// OpenRotatedTrace opens the trace segments written to dir by a RotatingTraceReporter and returns a
+// reader yielding their decompressed json events, oldest first.
+// A segment may lack its gzip trailer, e.g. if it is still being written to or the writing process
+// crashed; its contents are returned up to the last flushed batch of events. Empty segments, left by
+// a crash before the first flush, are skipped.
+func OpenRotatedTrace(dir string) (io.ReadCloser, error) {
+	segments, err := traceSegments(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &segmentReader{paths: segments}, nil
+}
+
+// traceSegments returns the paths of the trace segments in dir, oldest first.
+func traceSegments(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		if _, ok := traceSegmentSeq(e.Name()); ok {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		si, _ := traceSegmentSeq(names[i])
+		sj, _ := traceSegmentSeq(names[j])
+		return si < sj
+	})
+
+	segments := make([]string, 0, len(names))
+	for _, name := range names {
+		segments = append(segments, filepath.Join(dir, name))
+	}
+	return segments, nil
+}
+
+// traceSegmentSeq returns the sequence number of a trace segment file name.
+func traceSegmentSeq(name string) (int, bool) {
+	if !strings.HasPrefix(name, traceSegmentPrefix) || !strings.HasSuffix(name, traceSegmentSuffix) {
+		return 0, false
+	}
+	seq, err := strconv.Atoi(name[len(traceSegmentPrefix) : len(name)-len(traceSegmentSuffix)])
+	if err != nil || seq <= 0 {
+		return 0, false
+	}
+	return seq, true
+}
+
+type segmentReader struct {
+	paths []string
+
+	file *os.File
+	gzIn *gzip.Reader
+}
+
+func (r *segmentReader) Read(p []byte) (int, error) {
+	for {
+		if r.gzIn == nil {
+			if len(r.paths) == 0 {
+				return 0, io.EOF
+			}
+
+			file, err := os.Open(r.paths[0])
+			if err != nil {
+				return 0, err
+			}
+			r.paths = r.paths[1:]
+
+			gzIn, err := gzip.NewReader(file)
+			if err != nil {
+				file.Close()
+				// a crash before the first flush leaves a segment without even a gzip header
+				if err == io.EOF || err == io.ErrUnexpectedEOF {
+					continue
+				}
+				return 0, err
+			}
+			r.file, r.gzIn = file, gzIn
+		}
+
+		n, err := r.gzIn.Read(p)
+		// a segment may still be written to, or be left unfinished by a crash; after a restart the
+		// numbering continues, so the unfinished segment need not be the last one
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			r.closeSegment()
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+func (r *segmentReader) closeSegment() {
+	if r.gzIn != nil {
+		r.gzIn.Close()
+		r.file.Close()
+		r.gzIn, r.file = nil, nil
+	}
+}
+
+func (r *segmentReader) Close() error {
+	r.closeSegment()
+	r.paths = nil
+	return nil
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,155 @@
+package rcmgr
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func readRotatedTrace(t *testing.T, dir string) []string {
+	t.Helper()
+
+	in, err := OpenRotatedTrace(dir)
+	require.NoError(t, err)
+	defer in.Close()
+
+	var names []string
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		var evt struct{ Name string }
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &evt))
+		names = append(names, evt.Name)
+	}
+	require.NoError(t, scanner.Err())
+	return names
+}
+
+func traceBatch(start, n int) []TraceEvt {
+	batch := make([]TraceEvt, 0, n)
+	for i := start; i < start+n; i++ {
+		batch = append(batch, TraceEvt{Type: TraceCreateScopeEvt, Name: fmt.Sprintf("stream-%d", i)})
+	}
+	return batch
+}
+
+func TestRotatingTraceReporterRotation(t *testing.T) {
+	dir := t.TempDir()
+	r, err := NewRotatingTraceReporter(dir, 1, 3)
+	require.NoError(t, err)
+
+	// with a 1 byte cap, every batch ends up in its own segment
+	for i := 0; i < 5; i++ {
+		require.NoError(t, r.writeEvents(traceBatch(10*i, 10)))
+	}
+	require.NoError(t, r.Close())
+
+	segments, err := traceSegments(dir)
+	require.NoError(t, err)
+	require.Len(t, segments, 3)
+	require.Equal(t, fmt.Sprintf("%s%010d%s", traceSegmentPrefix, 3, traceSegmentSuffix), filepath.Base(segments[0]))
+
+	var expected []string
+	for _, evt := range traceBatch(20, 30) {
+		expected = append(expected, evt.Name)
+	}
+	require.Equal(t, expected, readRotatedTrace(t, dir))
+}
+
+func TestRotatingTraceReporterConsumeEvent(t *testing.T) {
+	dir := t.TempDir()
+	r, err := NewRotatingTraceReporter(dir, 1<<20, 3)
+	require.NoError(t, err)
+
+	for _, evt := range traceBatch(0, 100) {
+		r.ConsumeEvent(evt)
+	}
+	require.NoError(t, r.Close())
+	// events after close are dropped
+	r.ConsumeEvent(TraceEvt{Type: TraceCreateScopeEvt, Name: "stream-100"})
+
+	require.Len(t, readRotatedTrace(t, dir), 100)
+}
+
+func TestRotatingTraceReporterCloseError(t *testing.T) {
+	dir := t.TempDir()
+	r, err := NewRotatingTraceReporter(dir, 1<<20, 3)
+	require.NoError(t, err)
+
+	require.NoError(t, r.writeEvents(traceBatch(0, 10)))
+	// pull the segment file from under the background writer, so that the final flush fails
+	require.NoError(t, r.out.Close())
+	r.ConsumeEvent(TraceEvt{Type: TraceCreateScopeEvt, Name: "stream-10"})
+
+	require.Error(t, r.Close())
+	require.Error(t, r.Close())
+}
+
+func TestRotatingTraceReporterRestart(t *testing.T) {
+	dir := t.TempDir()
+
+	for run := 0; run < 2; run++ {
+		r, err := NewRotatingTraceReporter(dir, 1<<20, 10)
+		require.NoError(t, err)
+		require.NoError(t, r.writeEvents(traceBatch(10*run, 10)))
+		require.NoError(t, r.Close())
+	}
+
+	segments, err := traceSegments(dir)
+	require.NoError(t, err)
+	require.Len(t, segments, 2)
+	require.Len(t, readRotatedTrace(t, dir), 20)
+}
+
+func TestRotatedTraceUnfinishedSegment(t *testing.T) {
+	dir := t.TempDir()
+	r, err := NewRotatingTraceReporter(dir, 1<<20, 3)
+	require.NoError(t, err)
+
+	require.NoError(t, r.writeEvents(traceBatch(0, 10)))
+	require.NoError(t, r.closeSegment())
+	// the second segment is flushed but never closed, so it has no gzip trailer
+	require.NoError(t, r.writeEvents(traceBatch(10, 10)))
+	defer r.out.Close()
+
+	require.Len(t, readRotatedTrace(t, dir), 20)
+}
+
+func TestRotatedTraceTruncatedSegmentNotLast(t *testing.T) {
+	dir := t.TempDir()
+
+	// the first run crashes after flushing a batch, leaving its segment without a gzip trailer
+	r, err := NewRotatingTraceReporter(dir, 1<<20, 10)
+	require.NoError(t, err)
+	require.NoError(t, r.writeEvents(traceBatch(0, 10)))
+	require.NoError(t, r.out.Close())
+
+	// the second run crashes after opening its segment, before anything is flushed to it
+	r, err = NewRotatingTraceReporter(dir, 1<<20, 10)
+	require.NoError(t, err)
+	require.NoError(t, r.openSegment())
+	require.NoError(t, r.out.Close())
+
+	// the restarted run continues the numbering after the truncated and empty segments
+	r, err = NewRotatingTraceReporter(dir, 1<<20, 10)
+	require.NoError(t, err)
+	require.NoError(t, r.writeEvents(traceBatch(10, 10)))
+	require.NoError(t, r.Close())
+
+	segments, err := traceSegments(dir)
+	require.NoError(t, err)
+	require.Len(t, segments, 3)
+	info, err := os.Stat(segments[1])
+	require.NoError(t, err)
+	require.Zero(t, info.Size())
+
+	var expected []string
+	for _, evt := range traceBatch(0, 20) {
+		expected = append(expected, evt.Name)
+	}
+	require.Equal(t, expected, readRotatedTrace(t, dir))
+}
@@ -0,0 +1,75 @@
+package rcmgr
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type countingTraceReporter struct {
+	events []TraceEvt
+}
+
+func (r *countingTraceReporter) ConsumeEvent(evt TraceEvt) {
+	r.events = append(r.events, evt)
+}
+
+func TestSamplingTraceReporterBlockEvents(t *testing.T) {
+	for _, perScope := range []bool{false, true} {
+		inner := &countingTraceReporter{}
+		r := &SamplingTraceReporter{inner: inner, rate: 0, perScope: perScope}
+
+		for i := 0; i < 100; i++ {
+			name := fmt.Sprintf("peer:%d", i)
+			r.ConsumeEvent(TraceEvt{Type: TraceBlockReserveMemoryEvt, Name: name})
+			r.ConsumeEvent(TraceEvt{Type: TraceBlockAddStreamEvt, Name: name})
+			r.ConsumeEvent(TraceEvt{Type: TraceBlockAddConnEvt, Name: name})
+			r.ConsumeEvent(TraceEvt{Type: TraceReserveMemoryEvt, Name: name})
+		}
+		require.Len(t, inner.events, 300)
+	}
+}
+
+func TestSamplingTraceReporterRate(t *testing.T) {
+	const rate = 0.25
+	const n = 10000
+
+	t.Run("per event", func(t *testing.T) {
+		inner := &countingTraceReporter{}
+		r := NewSamplingTraceReporter(inner, rate)
+		for i := 0; i < n; i++ {
+			r.ConsumeEvent(TraceEvt{Type: TraceReserveMemoryEvt, Name: "system"})
+		}
+		require.InDelta(t, rate*n, len(inner.events), 0.05*n)
+	})
+
+	t.Run("per scope", func(t *testing.T) {
+		inner := &countingTraceReporter{}
+		r := NewScopeSamplingTraceReporter(inner, rate)
+		for i := 0; i < n; i++ {
+			r.ConsumeEvent(TraceEvt{Type: TraceReserveMemoryEvt, Name: fmt.Sprintf("stream-%d", i)})
+		}
+		require.InDelta(t, rate*n, len(inner.events), 0.05*n)
+	})
+}
+
+func TestScopeSamplingTraceReporterSpans(t *testing.T) {
+	inner := &countingTraceReporter{}
+	r := NewScopeSamplingTraceReporter(inner, 0.5)
+
+	for i := 0; i < 100; i++ {
+		owner := fmt.Sprintf("conn-%d", i)
+		r.ConsumeEvent(TraceEvt{Type: TraceReserveMemoryEvt, Name: owner})
+		before := len(inner.events)
+		for j := 0; j < 10; j++ {
+			r.ConsumeEvent(TraceEvt{Type: TraceReserveMemoryEvt, Name: fmt.Sprintf("%s.span-%d", owner, j)})
+		}
+		// spans are sampled together with their owner
+		if r.sample(owner) {
+			require.Equal(t, before+10, len(inner.events))
+		} else {
+			require.Equal(t, before, len(inner.events))
+		}
+	}
+}